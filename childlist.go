@@ -0,0 +1,298 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import "sort"
+
+// MaxChildrenPerSparseNode is the number of children a node
+// can hold in a sparseChildList before it is upgraded to a
+// dense representation. It may be tuned by callers before
+// building a trie; changing it afterwards only affects nodes
+// that are created or upgraded from that point on.
+var MaxChildrenPerSparseNode = 8
+
+// A childList is the storage a node uses for its children. It
+// is implemented by sparseChildList (a small sorted slice, used
+// by default so that leaves and near-leaves don't pay for a
+// map), denseChildList (a map, used once a node has many
+// children) and denseArrayChildList (a flat array, used instead
+// of denseChildList for nodes whose children are all ASCII).
+type childList interface {
+	get(r rune) *node
+	set(r rune, child *node)
+	remove(r rune)
+	len() int
+	// iter calls fn for every child; iteration stops early if
+	// fn returns false.
+	iter(fn func(r rune, child *node) bool)
+	// clone returns an independent copy of the list, so that
+	// mutating it never affects the original.
+	clone() childList
+}
+
+// getChild, setChild, removeChild, childLen and iterChildren
+// are the node-level entry points for childList access. They
+// tolerate a nil children field (an allocation-free leaf) and
+// own the sparse/dense upgrade and downgrade policy, so callers
+// never need to know which concrete childList a node holds.
+
+func (n *node) getChild(r rune) *node {
+	if n.children == nil {
+		return nil
+	}
+	return n.children.get(r)
+}
+
+func (n *node) childLen() int {
+	if n.children == nil {
+		return 0
+	}
+	return n.children.len()
+}
+
+func (n *node) iterChildren(fn func(r rune, child *node) bool) {
+	if n.children == nil {
+		return
+	}
+	n.children.iter(fn)
+}
+
+func (n *node) setChild(r rune, child *node) {
+	switch cl := n.children.(type) {
+	case nil:
+		sparse := newSparseChildList()
+		sparse.set(r, child)
+		n.children = sparse
+	case *sparseChildList:
+		if cl.get(r) == nil && cl.len() >= MaxChildrenPerSparseNode {
+			n.children = upgradeSparseChildList(cl, r, child)
+		} else {
+			cl.set(r, child)
+		}
+	case *denseArrayChildList:
+		if r < 0 || r >= denseArrayChildListSize {
+			dense := newDenseChildList()
+			cl.iter(func(r rune, child *node) bool {
+				dense.set(r, child)
+				return true
+			})
+			dense.set(r, child)
+			n.children = dense
+		} else {
+			cl.set(r, child)
+		}
+	default:
+		cl.set(r, child)
+	}
+}
+
+func (n *node) removeChild(r rune) {
+	if n.children == nil {
+		return
+	}
+	n.children.remove(r)
+
+	switch n.children.(type) {
+	case *sparseChildList:
+		return
+	}
+	if n.children.len() <= MaxChildrenPerSparseNode {
+		sparse := newSparseChildList()
+		n.children.iter(func(r rune, child *node) bool {
+			sparse.set(r, child)
+			return true
+		})
+		n.children = sparse
+	}
+}
+
+// upgradeSparseChildList converts a full sparseChildList into a
+// dense one and adds r/child to it. It picks a denseArrayChildList
+// when every existing rune (and the new one) is ASCII, since that
+// representation is faster than a map, and a denseChildList
+// otherwise.
+func upgradeSparseChildList(sparse *sparseChildList, r rune, child *node) childList {
+	ascii := r >= 0 && r < denseArrayChildListSize
+	if ascii {
+		sparse.iter(func(r rune, _ *node) bool {
+			if r < 0 || r >= denseArrayChildListSize {
+				ascii = false
+			}
+			return ascii
+		})
+	}
+
+	var dense childList
+	if ascii {
+		dense = newDenseArrayChildList()
+	} else {
+		dense = newDenseChildList()
+	}
+
+	sparse.iter(func(r rune, child *node) bool {
+		dense.set(r, child)
+		return true
+	})
+	dense.set(r, child)
+
+	return dense
+}
+
+type childEntry struct {
+	r     rune
+	child *node
+}
+
+// sparseChildList is a small sorted slice of children, searched
+// with a binary search. It is the default storage for a node's
+// children, so that leaves and near-leaves (the common case in
+// most word-like datasets) never allocate a map.
+type sparseChildList struct {
+	entries []childEntry
+}
+
+func newSparseChildList() *sparseChildList {
+	return &sparseChildList{}
+}
+
+func (l *sparseChildList) search(r rune) int {
+	return sort.Search(len(l.entries), func(i int) bool { return l.entries[i].r >= r })
+}
+
+func (l *sparseChildList) get(r rune) *node {
+	i := l.search(r)
+	if i < len(l.entries) && l.entries[i].r == r {
+		return l.entries[i].child
+	}
+	return nil
+}
+
+func (l *sparseChildList) set(r rune, child *node) {
+	i := l.search(r)
+	if i < len(l.entries) && l.entries[i].r == r {
+		l.entries[i].child = child
+		return
+	}
+	l.entries = append(l.entries, childEntry{})
+	copy(l.entries[i+1:], l.entries[i:])
+	l.entries[i] = childEntry{r: r, child: child}
+}
+
+func (l *sparseChildList) remove(r rune) {
+	i := l.search(r)
+	if i < len(l.entries) && l.entries[i].r == r {
+		l.entries = append(l.entries[:i], l.entries[i+1:]...)
+	}
+}
+
+func (l *sparseChildList) len() int {
+	return len(l.entries)
+}
+
+func (l *sparseChildList) iter(fn func(r rune, child *node) bool) {
+	for _, e := range l.entries {
+		if !fn(e.r, e.child) {
+			return
+		}
+	}
+}
+
+func (l *sparseChildList) clone() childList {
+	clone := &sparseChildList{entries: make([]childEntry, len(l.entries))}
+	copy(clone.entries, l.entries)
+	return clone
+}
+
+// denseChildList is a node's children backed by a plain map, as
+// used unconditionally before sparse/dense storage was added.
+type denseChildList struct {
+	m map[rune]*node
+}
+
+func newDenseChildList() *denseChildList {
+	return &denseChildList{m: make(map[rune]*node)}
+}
+
+func (l *denseChildList) get(r rune) *node { return l.m[r] }
+
+func (l *denseChildList) set(r rune, child *node) { l.m[r] = child }
+
+func (l *denseChildList) remove(r rune) { delete(l.m, r) }
+
+func (l *denseChildList) len() int { return len(l.m) }
+
+func (l *denseChildList) iter(fn func(r rune, child *node) bool) {
+	for r, child := range l.m {
+		if !fn(r, child) {
+			return
+		}
+	}
+}
+
+func (l *denseChildList) clone() childList {
+	clone := &denseChildList{m: make(map[rune]*node, len(l.m))}
+	for r, child := range l.m {
+		clone.m[r] = child
+	}
+	return clone
+}
+
+// denseArrayChildListSize covers the ASCII range, which is
+// where most ASCII-keyed tries spend nearly all of their nodes.
+const denseArrayChildListSize = 128
+
+// denseArrayChildList stores children in a flat array indexed
+// by rune, avoiding map overhead entirely for nodes whose
+// children are all ASCII.
+type denseArrayChildList struct {
+	children [denseArrayChildListSize]*node
+	count    int
+}
+
+func newDenseArrayChildList() *denseArrayChildList {
+	return &denseArrayChildList{}
+}
+
+func (l *denseArrayChildList) get(r rune) *node {
+	if r < 0 || r >= denseArrayChildListSize {
+		return nil
+	}
+	return l.children[r]
+}
+
+func (l *denseArrayChildList) set(r rune, child *node) {
+	if r < 0 || r >= denseArrayChildListSize {
+		return
+	}
+	if l.children[r] == nil && child != nil {
+		l.count++
+	} else if l.children[r] != nil && child == nil {
+		l.count--
+	}
+	l.children[r] = child
+}
+
+func (l *denseArrayChildList) remove(r rune) {
+	if r < 0 || r >= denseArrayChildListSize || l.children[r] == nil {
+		return
+	}
+	l.children[r] = nil
+	l.count--
+}
+
+func (l *denseArrayChildList) len() int { return l.count }
+
+func (l *denseArrayChildList) iter(fn func(r rune, child *node) bool) {
+	for r, child := range l.children {
+		if child != nil && !fn(rune(r), child) {
+			return
+		}
+	}
+}
+
+func (l *denseArrayChildList) clone() childList {
+	clone := &denseArrayChildList{children: l.children, count: l.count}
+	return clone
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import "testing"
+
+func TestSparseChildListIsDefault(t *testing.T) {
+	n := &node{}
+	n.setChild('a', &node{})
+
+	if _, ok := n.children.(*sparseChildList); !ok {
+		t.Errorf("A node's first child must be stored in a sparseChildList, got %T", n.children)
+	}
+}
+
+func TestChildListUpgradeASCII(t *testing.T) {
+	old := MaxChildrenPerSparseNode
+	MaxChildrenPerSparseNode = 4
+	defer func() { MaxChildrenPerSparseNode = old }()
+
+	n := &node{}
+	for r := rune('a'); r < 'a'+rune(MaxChildrenPerSparseNode)+1; r++ {
+		n.setChild(r, &node{})
+	}
+
+	if _, ok := n.children.(*denseArrayChildList); !ok {
+		t.Errorf("Exceeding MaxChildrenPerSparseNode with ASCII runes must upgrade to denseArrayChildList, got %T", n.children)
+	}
+	if n.childLen() != MaxChildrenPerSparseNode+1 {
+		t.Errorf("Invalid child count after upgrade: expected %v, got %v", MaxChildrenPerSparseNode+1, n.childLen())
+	}
+}
+
+func TestChildListUpgradeNonASCII(t *testing.T) {
+	old := MaxChildrenPerSparseNode
+	MaxChildrenPerSparseNode = 4
+	defer func() { MaxChildrenPerSparseNode = old }()
+
+	n := &node{}
+	for i := 0; i < MaxChildrenPerSparseNode+1; i++ {
+		n.setChild(rune(0x4e00+i), &node{}) // CJK ideographs, well outside the ASCII array
+	}
+
+	if _, ok := n.children.(*denseChildList); !ok {
+		t.Errorf("Exceeding MaxChildrenPerSparseNode with non-ASCII runes must upgrade to denseChildList, got %T", n.children)
+	}
+}
+
+func TestDenseArrayChildListUpgradesOnNonASCII(t *testing.T) {
+	old := MaxChildrenPerSparseNode
+	MaxChildrenPerSparseNode = 2
+	defer func() { MaxChildrenPerSparseNode = old }()
+
+	n := &node{}
+	n.setChild('a', &node{})
+	n.setChild('b', &node{})
+	n.setChild('c', &node{}) // triggers the ASCII upgrade
+
+	if _, ok := n.children.(*denseArrayChildList); !ok {
+		t.Fatalf("Expected denseArrayChildList, got %T", n.children)
+	}
+
+	n.setChild(0x4e2d, &node{}) // outside the array's ASCII range
+
+	if _, ok := n.children.(*denseChildList); !ok {
+		t.Errorf("Adding a non-ASCII rune to a denseArrayChildList must upgrade to denseChildList, got %T", n.children)
+	}
+	if n.childLen() != 4 {
+		t.Errorf("Invalid child count after upgrade: expected 4, got %v", n.childLen())
+	}
+}
+
+func TestChildListDowngradeOnRemove(t *testing.T) {
+	old := MaxChildrenPerSparseNode
+	MaxChildrenPerSparseNode = 2
+	defer func() { MaxChildrenPerSparseNode = old }()
+
+	n := &node{}
+	n.setChild('a', &node{})
+	n.setChild('b', &node{})
+	n.setChild('c', &node{})
+
+	if _, ok := n.children.(*sparseChildList); ok {
+		t.Fatalf("Node should have upgraded past sparse storage")
+	}
+
+	n.removeChild('c')
+
+	if _, ok := n.children.(*sparseChildList); !ok {
+		t.Errorf("Dropping back to MaxChildrenPerSparseNode children must downgrade to sparseChildList, got %T", n.children)
+	}
+	if n.childLen() != 2 {
+		t.Errorf("Invalid child count after downgrade: expected 2, got %v", n.childLen())
+	}
+}
+
+func BenchmarkSparseChildListSet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := newSparseChildList()
+		for r := rune('a'); r < 'a'+8; r++ {
+			l.set(r, &node{})
+		}
+	}
+}
+
+func BenchmarkDenseArrayChildListSet(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		l := newDenseArrayChildList()
+		for r := rune('a'); r < 'a'+8; r++ {
+			l.set(r, &node{})
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrEmptyPrefix is returned by GetByPrefix when called with
+// an empty prefix.
+var ErrEmptyPrefix = errors.New("trie: empty prefix")
+
+// ErrNotExist is returned by GetByPrefix when no stored key
+// starts with the given prefix.
+var ErrNotExist = errors.New("trie: no such key")
+
+// ErrAmbiguousPrefix is returned by GetByPrefix when more than
+// one stored key starts with the given prefix.
+type ErrAmbiguousPrefix struct {
+	Prefix string
+}
+
+func (e ErrAmbiguousPrefix) Error() string {
+	return fmt.Sprintf("trie: prefix %q is ambiguous", e.Prefix)
+}
+
+// GetByPrefix returns the single key/data pair whose key
+// starts with the given prefix, similar to Docker's short-ID
+// lookup (TruncIndex). It returns ErrEmptyPrefix for an empty
+// prefix, ErrNotExist if no key matches, and ErrAmbiguousPrefix
+// if more than one key matches.
+func (trie *Trie) GetByPrefix(prefix string) (key string, data interface{}, err error) {
+	if prefix == "" {
+		return "", nil, ErrEmptyPrefix
+	}
+
+	trie.rLock()
+	defer trie.rUnlock()
+
+	n, nodeKey, ok := trie.root.findPrefixNode(prefix)
+	if !ok {
+		return "", nil, ErrNotExist
+	}
+
+	count := 0
+	walk(n, nodeKey, func(k string, d interface{}) error {
+		count++
+		if count > 1 {
+			return StopWalk
+		}
+		key, data = k, d
+		return nil
+	}, false)
+
+	switch {
+	case count == 0:
+		return "", nil, ErrNotExist
+	case count > 1:
+		return "", nil, ErrAmbiguousPrefix{Prefix: prefix}
+	default:
+		return key, data, nil
+	}
+}
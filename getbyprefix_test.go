@@ -0,0 +1,61 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGetByPrefix(t *testing.T) {
+	trie := getTestTrie()
+
+	key, data, err := trie.GetByPrefix("foob")
+	if err != nil {
+		t.Errorf("GetByPrefix returned an unexpected error: %v", err)
+	}
+	if key != "foobar" || data != 111 {
+		t.Errorf("Invalid GetByPrefix result: expected (foobar, 111), got (%v, %v)", key, data)
+	}
+
+	key, data, err = trie.GetByPrefix("bar")
+	if err != nil {
+		t.Errorf("GetByPrefix returned an unexpected error: %v", err)
+	}
+	if key != "bar" || data != 22 {
+		t.Errorf("Invalid GetByPrefix result: expected (bar, 22), got (%v, %v)", key, data)
+	}
+}
+
+func TestGetByPrefixEmpty(t *testing.T) {
+	trie := getTestTrie()
+
+	_, _, err := trie.GetByPrefix("")
+	if err != ErrEmptyPrefix {
+		t.Errorf("Invalid error: expected %v, got %v", ErrEmptyPrefix, err)
+	}
+}
+
+func TestGetByPrefixNotExist(t *testing.T) {
+	trie := getTestTrie()
+
+	_, _, err := trie.GetByPrefix("xyz")
+	if err != ErrNotExist {
+		t.Errorf("Invalid error: expected %v, got %v", ErrNotExist, err)
+	}
+}
+
+func TestGetByPrefixAmbiguous(t *testing.T) {
+	trie := getTestTrie()
+
+	_, _, err := trie.GetByPrefix("foo")
+	var ambiguous ErrAmbiguousPrefix
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Invalid error: expected ErrAmbiguousPrefix, got %v", err)
+	}
+	if ambiguous.Prefix != "foo" {
+		t.Errorf("Invalid ambiguous prefix: expected foo, got %v", ambiguous.Prefix)
+	}
+}
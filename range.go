@@ -0,0 +1,118 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// LongestPrefixMatch walks the trie along key and returns the
+// deepest stored key that is itself a prefix of key, along with
+// its data. It is useful for longest-match routing tables, such
+// as IP prefixes or URL routers. ok is false if no stored key is
+// a prefix of key.
+func (trie *Trie) LongestPrefixMatch(key string) (matchedPrefix string, data interface{}, ok bool) {
+	trie.rLock()
+	defer trie.rUnlock()
+
+	n := trie.root
+	if n.data != nil {
+		matchedPrefix, data, ok = "", n.data, true
+	}
+
+	remaining := []rune(key)
+	consumed := make([]rune, 0, len(remaining))
+
+	for len(remaining) > 0 {
+		c := n.getChild(remaining[0])
+		if c == nil || len(remaining) < len(c.prefix) || !equalRunes(c.prefix, remaining[:len(c.prefix)]) {
+			break
+		}
+
+		consumed = append(consumed, c.prefix...)
+		remaining = remaining[len(c.prefix):]
+		n = c
+
+		if n.data != nil {
+			matchedPrefix, data, ok = string(consumed), n.data, true
+		}
+	}
+
+	return matchedPrefix, data, ok
+}
+
+// Range visits every stored key k with start <= k < end, in
+// lexicographic order, calling fn(k, data) for each. Iteration
+// stops early if fn returns false. end == "" means unbounded,
+// so trie.Range(prefix, trie.PrefixEnd(prefix), fn) iterates
+// exactly the keys starting with prefix without materializing a
+// map, and trie.Range(start, "", fn) iterates everything from
+// start onward.
+func (trie *Trie) Range(start, end string, fn func(key string, data interface{}) bool) {
+	trie.rLock()
+	defer trie.rUnlock()
+
+	rangeWalk(trie.root, "", start, end, fn)
+}
+
+// rangeWalk visits n and its descendants in lexicographic order,
+// calling fn for every key in [start, end). Unlike walk, it never
+// descends into a child whose entire subtree is guaranteed to
+// fall outside the bound: a child is skipped on the low side when
+// its key sorts below start without being one of start's leading
+// prefixes, and the walk stops altogether as soon as a key reaches
+// end, since sorted order means every later key would too. It
+// returns false once fn (or reaching end) says to stop, so the
+// caller can unwind without visiting the remaining siblings.
+func rangeWalk(n *node, key, start, end string, fn func(key string, data interface{}) bool) bool {
+	if n.data != nil && key >= start && (end == "" || key < end) {
+		if !fn(key, n.data) {
+			return false
+		}
+	}
+	if end != "" && key >= end {
+		return false
+	}
+
+	runes := make([]rune, 0, n.childLen())
+	n.iterChildren(func(r rune, _ *node) bool {
+		runes = append(runes, r)
+		return true
+	})
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	for _, r := range runes {
+		c := n.getChild(r)
+		childKey := key + string(c.prefix)
+		if childKey < start && !strings.HasPrefix(start, childKey) {
+			continue
+		}
+		if !rangeWalk(c, childKey, start, end, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// PrefixEnd returns the lexicographically smallest key that
+// sorts after every key prefixed by prefix, by incrementing its last
+// rune and carrying on overflow (mirroring Tendermint's
+// PrefixEndBytes). It returns "" if prefix is empty or made up
+// entirely of maximum-value runes, meaning there is no upper
+// bound short of unbounded.
+func PrefixEnd(prefix string) string {
+	runes := []rune(prefix)
+
+	for i := len(runes) - 1; i >= 0; i-- {
+		if runes[i] < utf8.MaxRune {
+			runes[i]++
+			return string(runes[:i+1])
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,151 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+	"unicode/utf8"
+)
+
+func TestLongestPrefixMatch(t *testing.T) {
+	trie := getTestTrie()
+
+	tests := []struct {
+		key        string
+		wantMatch  string
+		wantData   interface{}
+		wantExists bool
+	}{
+		{"foobarbaz", "foobar", 111, true},
+		{"foobar", "foobar", 111, true},
+		{"foo", "foo", 11, true},
+		{"foob", "foo", 11, true},
+		{"bart", "bar", 22, true},
+		{"xyz", "", nil, false},
+	}
+
+	for _, test := range tests {
+		match, data, ok := trie.LongestPrefixMatch(test.key)
+		if match != test.wantMatch || data != test.wantData || ok != test.wantExists {
+			t.Errorf("LongestPrefixMatch(%q): expected (%q, %v, %v), got (%q, %v, %v)",
+				test.key, test.wantMatch, test.wantData, test.wantExists, match, data, ok)
+		}
+	}
+}
+
+func TestRange(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	trie.Range("bar", "foobar", func(key string, data interface{}) bool {
+		got = append(got, key)
+		return true
+	})
+
+	want := []string{"bar", "foo"}
+	if len(got) != len(want) {
+		t.Fatalf("Invalid range result: expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Invalid range result: expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestRangeUnbounded(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	trie.Range("foo", "", func(key string, data interface{}) bool {
+		got = append(got, key)
+		return true
+	})
+
+	want := []string{"foo", "foobar"}
+	if len(got) != len(want) {
+		t.Fatalf("Invalid unbounded range result: expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Invalid unbounded range result: expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+func TestRangeStop(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	trie.Range("", "", func(key string, data interface{}) bool {
+		got = append(got, key)
+		return false
+	})
+
+	if len(got) != 1 {
+		t.Errorf("Returning false from Range's callback must stop after the first key, got %v", got)
+	}
+}
+
+func TestRangeWithPrefixEnd(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	trie.Range("foo", PrefixEnd("foo"), func(key string, data interface{}) bool {
+		got = append(got, key)
+		return true
+	})
+
+	want := []string{"foo", "foobar"}
+	if len(got) != len(want) {
+		t.Fatalf("Invalid prefix range result: expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Invalid prefix range result: expected %v, got %v", want, got)
+			break
+		}
+	}
+}
+
+// BenchmarkRangePrefix exercises the trie.Range(prefix,
+// trie.PrefixEnd(prefix), fn) idiom Range's doc comment
+// advertises, to confirm it stays competitive with
+// BenchmarkWithPrefixTrie rather than walking the whole trie.
+func BenchmarkRangePrefix(b *testing.B) {
+	b.ReportAllocs()
+	trie := getBenchTrie()
+
+	length := len(benchData)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		prefix := benchData[i%length]
+		trie.Range(prefix, PrefixEnd(prefix), func(key string, data interface{}) bool {
+			return true
+		})
+	}
+}
+
+func TestPrefixEnd(t *testing.T) {
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"foo", "fop"},
+		{"a", "b"},
+		{"", ""},
+		{string(rune(utf8.MaxRune)), ""},
+		{"a" + string(rune(utf8.MaxRune)), "b"},
+	}
+
+	for _, test := range tests {
+		got := PrefixEnd(test.prefix)
+		if got != test.want {
+			t.Errorf("PrefixEnd(%q): expected %q, got %q", test.prefix, test.want, got)
+		}
+	}
+}
@@ -9,61 +9,76 @@ package trie
 
 import "sync"
 
-// A Trie is an ordered tree data structure.
+// A Trie is an ordered tree data structure. Its nodes are
+// never mutated in place: Insert and Delete build a new path
+// from the root down to the affected node and swap it in under
+// mu, sharing every untouched subtree with the previous version.
+// This is what lets Snapshot hand out a read-only view that
+// needs no locking at all, see Snapshot and Txn.
 type Trie struct {
-	root *node
-	size int
-	nnum int
-	mu   sync.RWMutex
+	root      *node
+	size      int
+	nnum      int
+	radix     bool
+	immutable bool
+	mu        sync.RWMutex
 }
 
 type node struct {
-	symbol   rune
-	parent   *node
-	children map[rune]*node
+	prefix   []rune
+	children childList
 	data     interface{}
 }
 
-// NewTrie creates a new empty trie.
+// NewTrie creates a new empty trie. Each level of the
+// resulting tree holds a single rune per node.
 func NewTrie() *Trie {
 	return &Trie{
-		root: &node{
-			children: make(map[rune]*node),
-		},
+		root: &node{},
 		nnum: 1,
 	}
 }
 
+// NewRadixTrie creates a new empty radix (patricia) trie. It
+// offers the same API as a Trie created with NewTrie, but
+// collapses chains of single-child nodes into one node holding
+// a multi-rune prefix, which reduces both the node count and
+// the number of child lookups needed to reach a key. This trades
+// a little extra bookkeeping on Insert and Delete for cheaper
+// Search and WithPrefix on keys with long unique suffixes.
+func NewRadixTrie() *Trie {
+	return &Trie{
+		root:  &node{},
+		nnum:  1,
+		radix: true,
+	}
+}
+
 // Insert adds or replaces the data stored at the given key.
+//
+// Insert panics if called on an immutable snapshot returned by
+// Snapshot; use Txn to stage mutations instead.
 func (trie *Trie) Insert(key string, data interface{}) {
-	trie.mu.Lock()
-
-	n := trie.root
-	for _, r := range key {
-		c := n.children[r]
-		if c == nil {
-			c = &node{
-				symbol:   r,
-				parent:   n,
-				children: make(map[rune]*node),
-			}
-			n.children[r] = c
-			trie.nnum++
-		}
-		n = c
+	if trie.immutable {
+		panic("trie: cannot modify an immutable snapshot")
 	}
 
-	n.data = data
+	trie.mu.Lock()
 
-	trie.size++
+	newRoot, added, nnumDelta := cowInsert(trie.root, trie.radix, key, data)
+	trie.root = newRoot
+	trie.nnum += nnumDelta
+	if added {
+		trie.size++
+	}
 
 	trie.mu.Unlock()
 }
 
 // Search returns the data stored at the given key.
 func (trie *Trie) Search(key string) interface{} {
-	trie.mu.RLock()
-	defer trie.mu.RUnlock()
+	trie.rLock()
+	defer trie.rUnlock()
 
 	n := trie.root.findNode(key)
 	if n == nil {
@@ -77,30 +92,31 @@ func (trie *Trie) Search(key string) interface{} {
 func (trie *Trie) WithPrefix(prefix string) map[string]interface{} {
 	results := make(map[string]interface{})
 
-	trie.mu.RLock()
-	defer trie.mu.RUnlock()
+	trie.rLock()
+	defer trie.rUnlock()
 
-	n := trie.root.findNode(prefix)
-	if n == nil {
+	n, nodeKey, ok := trie.root.findPrefixNode(prefix)
+	if !ok {
 		return results
 	}
 
 	if n.data != nil {
-		results[prefix] = n.data
+		results[nodeKey] = n.data
 	}
 
 	// Explicit declaration is needed for recursion to work
 	var findResults func(*node, string)
-	findResults = func(n *node, prefix string) {
-		for r, c := range n.children {
-			childPrefix := prefix + string(r)
+	findResults = func(n *node, key string) {
+		n.iterChildren(func(_ rune, c *node) bool {
+			childKey := key + string(c.prefix)
 			if c.data != nil {
-				results[childPrefix] = c.data
+				results[childKey] = c.data
 			}
-			findResults(c, childPrefix)
-		}
+			findResults(c, childKey)
+			return true
+		})
 	}
-	findResults(n, prefix)
+	findResults(n, nodeKey)
 
 	return results
 }
@@ -108,25 +124,23 @@ func (trie *Trie) WithPrefix(prefix string) map[string]interface{} {
 // Delete removes the data stored at the given key and
 // returns true on success and false if the key wasn't
 // previously set.
+//
+// Delete panics if called on an immutable snapshot returned by
+// Snapshot; use Txn to stage mutations instead.
 func (trie *Trie) Delete(key string) bool {
+	if trie.immutable {
+		panic("trie: cannot modify an immutable snapshot")
+	}
+
 	trie.mu.Lock()
 	defer trie.mu.Unlock()
 
-	n := trie.root.findNode(key)
-	if n == nil || n.data == nil {
+	newRoot, deleted, nnumDelta := cowDeleteRoot(trie.root, trie.radix, key)
+	if !deleted {
 		return false
 	}
-
-	n.data = nil
-
-	for n.data == nil && len(n.children) == 0 && n.parent != nil {
-		parent := n.parent
-		delete(parent.children, n.symbol)
-		n.parent = nil
-		n = parent
-		trie.nnum--
-	}
-
+	trie.root = newRoot
+	trie.nnum += nnumDelta
 	trie.size--
 
 	return true
@@ -134,26 +148,109 @@ func (trie *Trie) Delete(key string) bool {
 
 // Len returns the total number of keys stored in the trie.
 func (trie *Trie) Len() int {
-	trie.mu.RLock()
-	defer trie.mu.RUnlock()
+	trie.rLock()
+	defer trie.rUnlock()
 	return trie.size
 }
 
 // NodeNum returns the total number of internal nodes
 // in the trie, which can be useful for debugging.
 func (trie *Trie) NodeNum() int {
-	trie.mu.RLock()
-	defer trie.mu.RUnlock()
+	trie.rLock()
+	defer trie.rUnlock()
 	return trie.nnum
 }
 
-// Ensure it is called inside the mutex lock
+// rLock acquires mu for reading, unless trie is an immutable
+// snapshot, whose tree is never mutated again and therefore
+// needs no locking at all.
+func (trie *Trie) rLock() {
+	if !trie.immutable {
+		trie.mu.RLock()
+	}
+}
+
+func (trie *Trie) rUnlock() {
+	if !trie.immutable {
+		trie.mu.RUnlock()
+	}
+}
+
+// Ensure it is called inside the mutex lock, or on an immutable snapshot
 func (n *node) findNode(key string) *node {
-	for _, r := range key {
-		n = n.children[r]
-		if n == nil {
+	remaining := []rune(key)
+	for len(remaining) > 0 {
+		c := n.getChild(remaining[0])
+		if c == nil || len(remaining) < len(c.prefix) || !equalRunes(c.prefix, remaining[:len(c.prefix)]) {
 			return nil
 		}
+		remaining = remaining[len(c.prefix):]
+		n = c
 	}
 	return n
 }
+
+// findPrefixNode walks down from n along key and returns the
+// deepest node covering it, along with that node's own full
+// key. Unlike findNode, a key that ends in the middle of a
+// node's compressed prefix still counts as a match: the
+// returned node is the one whose prefix the key is itself a
+// prefix of, and nodeKey is extended to that node's full key
+// so the caller can enumerate its subtree correctly.
+//
+// Ensure it is called inside the mutex lock, or on an immutable snapshot
+func (n *node) findPrefixNode(key string) (match *node, nodeKey string, ok bool) {
+	remaining := []rune(key)
+	consumed := make([]rune, 0, len(remaining))
+
+	for len(remaining) > 0 {
+		c := n.getChild(remaining[0])
+		if c == nil {
+			return nil, "", false
+		}
+
+		if len(remaining) >= len(c.prefix) {
+			if !equalRunes(c.prefix, remaining[:len(c.prefix)]) {
+				return nil, "", false
+			}
+			consumed = append(consumed, c.prefix...)
+			remaining = remaining[len(c.prefix):]
+			n = c
+			continue
+		}
+
+		if !equalRunes(remaining, c.prefix[:len(remaining)]) {
+			return nil, "", false
+		}
+		consumed = append(consumed, c.prefix...)
+		return c, string(consumed), true
+	}
+
+	return n, string(consumed), true
+}
+
+// commonPrefixLen returns the length of the longest leading
+// run of runes shared by a and b.
+func commonPrefixLen(a, b []rune) int {
+	max := len(a)
+	if len(b) < max {
+		max = len(b)
+	}
+	i := 0
+	for i < max && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func equalRunes(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -261,6 +261,152 @@ func TestCounters(t *testing.T) {
 	}
 }
 
+func getTestRadixTrie() *Trie {
+	trie := NewRadixTrie()
+	for _, item := range testData {
+		trie.Insert(item.Key, item.Value)
+	}
+	return trie
+}
+
+func TestNewRadixTrie(t *testing.T) {
+	var trie interface{} = NewRadixTrie()
+	_, ok := trie.(*Trie)
+	if !ok {
+		t.Errorf("Invalid trie type")
+	}
+}
+
+func TestRadixInsertAndSearch(t *testing.T) {
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"foo", 11},
+		{"foobar", 111},
+		{"bar", 22},
+		{"foob", nil},
+		{"foobarr", nil},
+	}
+
+	trie := getTestRadixTrie()
+
+	for _, test := range tests {
+		value := trie.Search(test.key)
+		if value != test.want {
+			t.Errorf("Invalid value: expected %v, got %v", test.want, value)
+		}
+	}
+}
+
+func TestRadixWithPrefix(t *testing.T) {
+	tests := []struct {
+		key  string
+		want map[string]interface{}
+	}{
+		{"f", map[string]interface{}{
+			"foo":    11,
+			"foobar": 111,
+		}},
+		{"foo", map[string]interface{}{
+			"foo":    11,
+			"foobar": 111,
+		}},
+		{"foob", map[string]interface{}{
+			"foobar": 111,
+		}},
+		{"ba", map[string]interface{}{
+			"bar": 22,
+		}},
+		{"xyz", map[string]interface{}{}},
+	}
+
+	trie := getTestRadixTrie()
+
+	for _, test := range tests {
+		value := trie.WithPrefix(test.key)
+		if !reflect.DeepEqual(value, test.want) {
+			t.Errorf("Invalid prefix values: expected %v, got %v", test.want, value)
+		}
+	}
+}
+
+func TestRadixDelete(t *testing.T) {
+	tests := []struct {
+		key  string
+		want interface{}
+	}{
+		{"foo", 11},
+		{"bar", 22},
+		{"foobar", nil},
+		{"fooba", nil},
+		{"foob", nil},
+	}
+
+	trie := getTestRadixTrie()
+
+	ok := trie.Delete("foob")
+	if ok {
+		t.Errorf("Deleting unexisting key must return false")
+	}
+
+	ok = trie.Delete("foobar")
+	if !ok {
+		t.Errorf("Deleting existing key must return true")
+	}
+
+	for _, test := range tests {
+		value := trie.Search(test.key)
+		if value != test.want {
+			t.Errorf("Invalid value: expected %v, got %v", test.want, value)
+		}
+	}
+}
+
+// TestRadixNodeCount checks that the radix trie collapses
+// chains of single-child nodes: "foo" and "foobar" share the
+// "foo" prefix, so inserting both should need only two nodes
+// past the root, as opposed to six for the uncompressed trie.
+func TestRadixNodeCount(t *testing.T) {
+	trie := NewRadixTrie()
+	trie.Insert("foo", 11)
+	trie.Insert("foobar", 111)
+
+	if want := 1 + 2; trie.NodeNum() != want {
+		t.Errorf("Invalid radix trie node number: expected %v, got %v", want, trie.NodeNum())
+	}
+}
+
+// TestRadixInsertSplitCollision exercises an insert that splits
+// an existing node at a point where the diverging rune happens
+// to match one of that node's own pre-split child keys. A stale
+// reference to the pre-split node there must not resurrect its
+// old subtree under the new, unrelated key.
+func TestRadixInsertSplitCollision(t *testing.T) {
+	trie := NewRadixTrie()
+	trie.Insert("bbx", 1)
+	trie.Insert("bbxa", 11)
+	trie.Insert("bby", 2)
+	trie.Insert("bx", 3)
+
+	want := map[string]interface{}{
+		"bbx":  1,
+		"bbxa": 11,
+		"bby":  2,
+		"bx":   3,
+	}
+
+	got := make(map[string]interface{})
+	trie.Walk(func(key string, data interface{}) error {
+		got[key] = data
+		return nil
+	})
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid radix trie contents after split: expected %v, got %v", want, got)
+	}
+}
+
 func getBenchTrie() *Trie {
 	trie := NewTrie()
 	for _, key := range benchData {
@@ -277,6 +423,14 @@ func getBenchPrefixMap() PrefixMap {
 	return m
 }
 
+func getBenchRadixTrie() *Trie {
+	trie := NewRadixTrie()
+	for _, key := range benchData {
+		trie.Insert(key, struct{}{})
+	}
+	return trie
+}
+
 func BenchmarkWithPrefixTrie(b *testing.B) {
 	b.ReportAllocs()
 	trie := getBenchTrie()
@@ -288,6 +442,17 @@ func BenchmarkWithPrefixTrie(b *testing.B) {
 	}
 }
 
+func BenchmarkWithPrefixRadixTrie(b *testing.B) {
+	b.ReportAllocs()
+	trie := getBenchRadixTrie()
+
+	length := len(benchData)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = trie.WithPrefix(benchData[i%length])
+	}
+}
+
 func BenchmarkWithPrefixMap(b *testing.B) {
 	b.ReportAllocs()
 	m := getBenchPrefixMap()
@@ -309,6 +474,16 @@ func BenchmarkInsertTrie(b *testing.B) {
 	}
 }
 
+func BenchmarkInsertRadixTrie(b *testing.B) {
+	b.ReportAllocs()
+	trie := NewRadixTrie()
+	length := len(benchData)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		trie.Insert(benchData[i%length], struct{}{})
+	}
+}
+
 func BenchmarkInsertMap(b *testing.B) {
 	b.ReportAllocs()
 	m := make(map[string]interface{})
@@ -330,6 +505,17 @@ func BenchmarkSearchTrie(b *testing.B) {
 	}
 }
 
+func BenchmarkSearchRadixTrie(b *testing.B) {
+	b.ReportAllocs()
+	trie := getBenchRadixTrie()
+
+	length := len(benchData)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = trie.Search(benchData[i%length])
+	}
+}
+
 func BenchmarkSearchMap(b *testing.B) {
 	b.ReportAllocs()
 	m := getBenchPrefixMap()
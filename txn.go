@@ -0,0 +1,295 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+// Snapshot returns an immutable view of the trie as of the
+// moment it was taken. It shares its node structure with the
+// live trie, which is safe because Insert and Delete never
+// mutate an existing node in place: they build a new path down
+// to the affected node and swap it in, leaving every node a
+// snapshot might still be reading untouched. Search, WithPrefix,
+// Walk, WalkPrefix, WalkSorted and GetByPrefix can therefore run
+// against a Snapshot without taking any lock.
+//
+// The returned Trie is read-only: calling Insert or Delete on
+// it panics. Use Txn to stage further mutations.
+func (trie *Trie) Snapshot() *Trie {
+	trie.mu.RLock()
+	defer trie.mu.RUnlock()
+
+	return &Trie{
+		root:      trie.root,
+		size:      trie.size,
+		nnum:      trie.nnum,
+		radix:     trie.radix,
+		immutable: true,
+	}
+}
+
+// A Txn is an in-progress, copy-on-write transaction against a
+// Trie. It accumulates Insert and Delete calls against a
+// private root, invisible to the trie it was created from and
+// to any other Txn, until Commit atomically publishes it.
+//
+// A Txn is not safe for concurrent use by multiple goroutines.
+type Txn struct {
+	trie  *Trie
+	root  *node
+	radix bool
+	size  int
+	nnum  int
+}
+
+// Txn starts a new transaction against the trie. Reads against
+// the trie (or any Snapshot taken before Commit) are unaffected
+// by the transaction until it is committed.
+func (trie *Trie) Txn() *Txn {
+	trie.mu.RLock()
+	defer trie.mu.RUnlock()
+
+	return &Txn{
+		trie:  trie,
+		root:  trie.root,
+		radix: trie.radix,
+		size:  trie.size,
+		nnum:  trie.nnum,
+	}
+}
+
+// Insert adds or replaces the data stored at the given key
+// within the transaction.
+func (txn *Txn) Insert(key string, data interface{}) {
+	newRoot, added, nnumDelta := cowInsert(txn.root, txn.radix, key, data)
+	txn.root = newRoot
+	txn.nnum += nnumDelta
+	if added {
+		txn.size++
+	}
+}
+
+// Delete removes the data stored at the given key within the
+// transaction, returning true on success and false if the key
+// wasn't previously set.
+func (txn *Txn) Delete(key string) bool {
+	newRoot, deleted, nnumDelta := cowDeleteRoot(txn.root, txn.radix, key)
+	if !deleted {
+		return false
+	}
+	txn.root = newRoot
+	txn.nnum += nnumDelta
+	txn.size--
+	return true
+}
+
+// Commit atomically swaps the transaction's root into the
+// trie it was created from and returns that trie. Any
+// Snapshot taken before Commit keeps seeing the pre-commit
+// state.
+func (txn *Txn) Commit() *Trie {
+	txn.trie.mu.Lock()
+	defer txn.trie.mu.Unlock()
+
+	txn.trie.root = txn.root
+	txn.trie.size = txn.size
+	txn.trie.nnum = txn.nnum
+
+	return txn.trie
+}
+
+// Abort discards the transaction. Since a Txn only ever
+// mutates its own private root, simply ceasing to use it (and
+// never calling Commit) is equivalent; Abort exists to make
+// that intent explicit at call sites.
+func (txn *Txn) Abort() {
+	txn.root = nil
+}
+
+// cowInsert returns a new root equal to root with key/data
+// inserted, sharing every subtree untouched by the insertion
+// with root. Trie.Insert and Txn.Insert are both built on it; it
+// clones every node along the path instead of mutating it.
+func cowInsert(root *node, radix bool, key string, data interface{}) (newRoot *node, added bool, nnumDelta int) {
+	remaining := []rune(key)
+
+	newRoot = cloneNode(root)
+	cur := newRoot
+
+	for len(remaining) > 0 {
+		r := remaining[0]
+		// cur is always a clone whose children list was copied
+		// from the original it replaced, so querying cur itself
+		// (rather than keeping a separate pointer into the
+		// original tree) stays correct even after a split below:
+		// the split leaves cur with only its new tail child, so a
+		// diverging rune correctly finds nothing further.
+		origChild := cur.getChild(r)
+
+		var child *node
+		if origChild == nil {
+			prefix := remaining
+			if !radix {
+				prefix = remaining[:1]
+			}
+			child = &node{
+				prefix: append([]rune(nil), prefix...),
+			}
+			nnumDelta++
+			remaining = remaining[len(prefix):]
+		} else {
+			child = cloneNode(origChild)
+			common := commonPrefixLen(origChild.prefix, remaining)
+			if common < len(origChild.prefix) {
+				splitClone(child, common)
+				nnumDelta++
+			}
+			remaining = remaining[common:]
+		}
+
+		cur.setChild(r, child)
+		cur = child
+	}
+
+	added = cur.data == nil
+	cur.data = data
+
+	return newRoot, added, nnumDelta
+}
+
+// splitClone divides a clone's prefix at offset common: the
+// clone keeps the leading common part and a freshly created
+// child ("tail") takes over the remaining suffix together with
+// the clone's former data and children. cowInsert uses it when a
+// key diverges in the middle of a node's prefix.
+func splitClone(clone *node, common int) {
+	tail := &node{
+		prefix:   append([]rune(nil), clone.prefix[common:]...),
+		children: clone.children,
+		data:     clone.data,
+	}
+
+	clone.prefix = append([]rune(nil), clone.prefix[:common]...)
+	clone.children = nil
+	clone.setChild(tail.prefix[0], tail)
+	clone.data = nil
+}
+
+// cowDeleteRoot deletes key from root, returning a new root
+// sharing every untouched subtree with root. The root itself is
+// never pruned or merged away. Trie.Delete and Txn.Delete are
+// both built on it.
+func cowDeleteRoot(root *node, radix bool, key string) (newRoot *node, deleted bool, nnumDelta int) {
+	remaining := []rune(key)
+
+	if len(remaining) == 0 {
+		if root.data == nil {
+			return root, false, 0
+		}
+		clone := cloneNode(root)
+		clone.data = nil
+		return clone, true, 0
+	}
+
+	r := remaining[0]
+	c := root.getChild(r)
+	if c == nil || len(remaining) < len(c.prefix) || !equalRunes(c.prefix, remaining[:len(c.prefix)]) {
+		return root, false, 0
+	}
+
+	newChild, deleted, delta := cowDelete(c, radix, remaining[len(c.prefix):])
+	if !deleted {
+		return root, false, 0
+	}
+
+	clone := cloneNode(root)
+	if newChild == nil {
+		clone.removeChild(r)
+	} else {
+		clone.setChild(r, newChild)
+	}
+
+	return clone, true, delta
+}
+
+// cowDelete deletes remaining from n, returning the replacement
+// for n (nil if n should be removed from its parent), whether a
+// key was actually deleted, and the node-count delta. Unchanged
+// subtrees are returned unmodified rather than cloned.
+func cowDelete(n *node, radix bool, remaining []rune) (result *node, deleted bool, nnumDelta int) {
+	if len(remaining) == 0 {
+		if n.data == nil {
+			return n, false, 0
+		}
+		clone := cloneNode(n)
+		clone.data = nil
+		result, nnumDelta = collapse(clone, radix)
+		return result, true, nnumDelta
+	}
+
+	r := remaining[0]
+	c := n.getChild(r)
+	if c == nil || len(remaining) < len(c.prefix) || !equalRunes(c.prefix, remaining[:len(c.prefix)]) {
+		return n, false, 0
+	}
+
+	newChild, deleted, delta := cowDelete(c, radix, remaining[len(c.prefix):])
+	if !deleted {
+		return n, false, 0
+	}
+
+	clone := cloneNode(n)
+	if newChild == nil {
+		clone.removeChild(r)
+	} else {
+		clone.setChild(r, newChild)
+	}
+
+	result, d := collapse(clone, radix)
+	return result, true, delta + d
+}
+
+// collapse prunes a clone that ended up with no data and no
+// children, or merges it into its sole remaining child (the
+// inverse of splitClone). It returns nil when the clone should
+// be removed from its parent, along with the resulting
+// node-count delta, which already counts that removal.
+func collapse(n *node, radix bool) (*node, int) {
+	if n.data == nil && n.childLen() == 0 {
+		return nil, -1
+	}
+
+	if radix && n.data == nil && n.childLen() == 1 {
+		var child *node
+		n.iterChildren(func(_ rune, c *node) bool {
+			child = c
+			return false
+		})
+
+		n.prefix = append(n.prefix, child.prefix...)
+		if child.children != nil {
+			n.children = child.children.clone()
+		} else {
+			n.children = nil
+		}
+		n.data = child.data
+
+		return n, -1
+	}
+
+	return n, 0
+}
+
+// cloneNode returns a shallow copy of n with its own prefix
+// slice and children list, so that mutating the clone can never
+// affect n or any node reachable only from n.
+func cloneNode(n *node) *node {
+	clone := &node{
+		prefix: append([]rune(nil), n.prefix...),
+		data:   n.data,
+	}
+	if n.children != nil {
+		clone.children = n.children.clone()
+	}
+	return clone
+}
@@ -0,0 +1,169 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	trie := getTestTrie()
+
+	snap := trie.Snapshot()
+
+	trie.Insert("baz", 33)
+	trie.Delete("bar")
+
+	if snap.Search("baz") != nil {
+		t.Errorf("Snapshot must not see inserts made after it was taken")
+	}
+	if snap.Search("bar") != 22 {
+		t.Errorf("Snapshot must not see deletes made after it was taken")
+	}
+
+	if trie.Search("baz") != 33 {
+		t.Errorf("Live trie must see its own insert")
+	}
+	if trie.Search("bar") != nil {
+		t.Errorf("Live trie must see its own delete")
+	}
+}
+
+func TestSnapshotImmutable(t *testing.T) {
+	trie := getTestTrie()
+	snap := trie.Snapshot()
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("Insert on a snapshot must panic")
+		}
+	}()
+	snap.Insert("baz", 33)
+}
+
+func TestTxnCommit(t *testing.T) {
+	trie := getTestTrie()
+	snap := trie.Snapshot()
+
+	txn := trie.Txn()
+	txn.Insert("baz", 33)
+	txn.Delete("bar")
+
+	if trie.Search("baz") != nil {
+		t.Errorf("Uncommitted txn must not be visible on the live trie")
+	}
+	if snap.Search("bar") != 22 {
+		t.Errorf("Uncommitted txn must not be visible on an existing snapshot")
+	}
+
+	committed := txn.Commit()
+	if committed != trie {
+		t.Errorf("Commit must return the trie the Txn was created from")
+	}
+
+	if trie.Search("baz") != 33 {
+		t.Errorf("Committed insert must be visible on the live trie")
+	}
+	if trie.Search("bar") != nil {
+		t.Errorf("Committed delete must be visible on the live trie")
+	}
+	if snap.Search("bar") != 22 {
+		t.Errorf("A snapshot taken before Commit must still see the old state")
+	}
+}
+
+func TestTxnAbort(t *testing.T) {
+	trie := getTestTrie()
+
+	txn := trie.Txn()
+	txn.Insert("baz", 33)
+	txn.Abort()
+
+	if trie.Search("baz") != nil {
+		t.Errorf("Aborted txn must not affect the live trie")
+	}
+}
+
+func TestTxnCounters(t *testing.T) {
+	trie := NewRadixTrie()
+	txn := trie.Txn()
+	for _, item := range testData {
+		txn.Insert(item.Key, item.Value)
+	}
+	txn.Commit()
+
+	want := NewRadixTrie()
+	for _, item := range testData {
+		want.Insert(item.Key, item.Value)
+	}
+
+	if trie.Len() != want.Len() {
+		t.Errorf("Invalid txn trie length: expected %v, got %v", want.Len(), trie.Len())
+	}
+	if trie.NodeNum() != want.NodeNum() {
+		t.Errorf("Invalid txn trie node number: expected %v, got %v", want.NodeNum(), trie.NodeNum())
+	}
+}
+
+// TestTxnDeleteMergeIntoLeaf exercises collapse's merge branch
+// where the surviving sibling is itself a leaf (nil children),
+// the common case under sparse storage: merging must not assume
+// the sibling already has a children list to clone.
+func TestTxnDeleteMergeIntoLeaf(t *testing.T) {
+	trie := NewRadixTrie()
+	trie.Insert("ab", 1)
+	trie.Insert("ac", 2)
+
+	txn := trie.Txn()
+	if !txn.Delete("ab") {
+		t.Fatalf("Delete of existing key must succeed")
+	}
+	txn.Commit()
+
+	if trie.Search("ab") != nil {
+		t.Errorf("Deleted key must no longer be found")
+	}
+	if trie.Search("ac") != 2 {
+		t.Errorf("Surviving sibling must still be found after merge")
+	}
+}
+
+func getBenchSnapshot() *Trie {
+	return getBenchTrie().Snapshot()
+}
+
+func BenchmarkSearchSnapshot(b *testing.B) {
+	b.ReportAllocs()
+	snap := getBenchSnapshot()
+
+	length := len(benchData)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = snap.Search(benchData[i%length])
+	}
+}
+
+// BenchmarkSearchWhileInsertSnapshot reads a Snapshot taken
+// once up front while the live trie keeps mutating in the
+// background, exercising the lock-free read path instead of
+// the mu.RWMutex contention BenchmarkSearchWhileInsertParallel
+// measures on the live trie.
+func BenchmarkSearchWhileInsertSnapshot(b *testing.B) {
+	b.ReportAllocs()
+	trie := getBenchTrie()
+	snap := trie.Snapshot()
+
+	length := len(benchData)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			key := benchData[i%length]
+			go trie.Insert(key, struct{}{})
+			_ = snap.Search(key)
+			i++
+		}
+	})
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"errors"
+	"sort"
+)
+
+// SkipSubtree can be returned by a WalkFunc to skip the
+// children of the node currently being visited, without
+// stopping the walk as a whole.
+var SkipSubtree = errors.New("trie: skip subtree")
+
+// StopWalk can be returned by a WalkFunc to abort the walk
+// immediately. It is not propagated to the caller: Walk,
+// WalkPrefix and WalkSorted return nil when stopped this way.
+var StopWalk = errors.New("trie: stop walk")
+
+// A WalkFunc is called once for every key/data pair visited by
+// Walk, WalkPrefix or WalkSorted. Returning SkipSubtree or
+// StopWalk controls the walk as described above; any other
+// non-nil error aborts the walk and is returned to the caller.
+//
+// The walk holds the trie's read lock for its entire duration
+// (unless it is running against an immutable Snapshot, which
+// needs no lock at all), so a WalkFunc must never call a method
+// that mutates the trie (Insert, Delete) or the walk will
+// deadlock.
+type WalkFunc func(key string, data interface{}) error
+
+// Walk visits every key/data pair stored in the trie in an
+// unspecified order. See WalkFunc for the control flow rules.
+func (trie *Trie) Walk(fn WalkFunc) error {
+	trie.rLock()
+	defer trie.rUnlock()
+
+	return stopOK(walk(trie.root, "", fn, false))
+}
+
+// WalkPrefix visits every key/data pair whose key starts with
+// the given prefix, in an unspecified order. See WalkFunc for
+// the control flow rules.
+func (trie *Trie) WalkPrefix(prefix string, fn WalkFunc) error {
+	trie.rLock()
+	defer trie.rUnlock()
+
+	n, nodeKey, ok := trie.root.findPrefixNode(prefix)
+	if !ok {
+		return nil
+	}
+	return stopOK(walk(n, nodeKey, fn, false))
+}
+
+// WalkSorted visits every key/data pair stored in the trie in
+// lexicographic key order, which makes it suitable for
+// reproducible output and range-style queries. See WalkFunc
+// for the control flow rules.
+func (trie *Trie) WalkSorted(fn WalkFunc) error {
+	trie.rLock()
+	defer trie.rUnlock()
+
+	return stopOK(walk(trie.root, "", fn, true))
+}
+
+// stopOK turns the internal StopWalk sentinel into a nil error,
+// since it represents deliberate early termination rather than
+// a failure to report to the caller.
+func stopOK(err error) error {
+	if err == StopWalk {
+		return nil
+	}
+	return err
+}
+
+func walk(n *node, key string, fn WalkFunc, sorted bool) error {
+	if n.data != nil {
+		if err := fn(key, n.data); err != nil {
+			if err == SkipSubtree {
+				return nil
+			}
+			return err
+		}
+	}
+
+	if !sorted {
+		var childErr error
+		n.iterChildren(func(_ rune, c *node) bool {
+			childErr = walk(c, key+string(c.prefix), fn, sorted)
+			return childErr == nil
+		})
+		return childErr
+	}
+
+	runes := make([]rune, 0, n.childLen())
+	n.iterChildren(func(r rune, _ *node) bool {
+		runes = append(runes, r)
+		return true
+	})
+	sort.Slice(runes, func(i, j int) bool { return runes[i] < runes[j] })
+
+	for _, r := range runes {
+		c := n.getChild(r)
+		if err := walk(c, key+string(c.prefix), fn, sorted); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Pavel Knoblokh. All rights reserved.
+// Use of this source code is governed by MIT License
+// that can be found in the LICENSE file.
+
+package trie
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	trie := getTestTrie()
+
+	got := make(map[string]interface{})
+	err := trie.Walk(func(key string, data interface{}) error {
+		got[key] = data
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Walk returned an unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"foo":    11,
+		"foobar": 111,
+		"bar":    22,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid walk results: expected %v, got %v", want, got)
+	}
+}
+
+func TestWalkPrefix(t *testing.T) {
+	trie := getTestTrie()
+
+	got := make(map[string]interface{})
+	err := trie.WalkPrefix("foo", func(key string, data interface{}) error {
+		got[key] = data
+		return nil
+	})
+	if err != nil {
+		t.Errorf("WalkPrefix returned an unexpected error: %v", err)
+	}
+
+	want := map[string]interface{}{
+		"foo":    11,
+		"foobar": 111,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid walk prefix results: expected %v, got %v", want, got)
+	}
+
+	got = make(map[string]interface{})
+	err = trie.WalkPrefix("xyz", func(key string, data interface{}) error {
+		got[key] = data
+		return nil
+	})
+	if err != nil {
+		t.Errorf("WalkPrefix returned an unexpected error: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("WalkPrefix on an unmatched prefix must visit nothing, got %v", got)
+	}
+}
+
+func TestWalkSorted(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	err := trie.WalkSorted(func(key string, data interface{}) error {
+		got = append(got, key)
+		return nil
+	})
+	if err != nil {
+		t.Errorf("WalkSorted returned an unexpected error: %v", err)
+	}
+
+	want := []string{"bar", "foo", "foobar"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Invalid sorted walk order: expected %v, got %v", want, got)
+	}
+}
+
+func TestWalkSkipSubtree(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	err := trie.WalkSorted(func(key string, data interface{}) error {
+		got = append(got, key)
+		if key == "foo" {
+			return SkipSubtree
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("WalkSorted returned an unexpected error: %v", err)
+	}
+
+	want := []string{"bar", "foo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SkipSubtree must prune foobar below foo: expected %v, got %v", want, got)
+	}
+}
+
+func TestWalkStopWalk(t *testing.T) {
+	trie := getTestTrie()
+
+	var got []string
+	err := trie.WalkSorted(func(key string, data interface{}) error {
+		got = append(got, key)
+		if key == "foo" {
+			return StopWalk
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("WalkSorted must not surface StopWalk to the caller, got %v", err)
+	}
+
+	want := []string{"bar", "foo"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("StopWalk must abort the walk immediately: expected %v, got %v", want, got)
+	}
+}
+
+func TestWalkError(t *testing.T) {
+	trie := getTestTrie()
+
+	boom := errors.New("boom")
+	err := trie.Walk(func(key string, data interface{}) error {
+		return boom
+	})
+	if err != boom {
+		t.Errorf("Walk must surface a non-sentinel error: expected %v, got %v", boom, err)
+	}
+}